@@ -18,6 +18,7 @@ import (
 	"github.com/pingcap/tidb-tools/pkg/check"
 	"github.com/pingcap/tidb-tools/pkg/dbutil"
 	"github.com/pingcap/tidb-tools/pkg/table-router"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/siddontang/go/sync2"
 	"golang.org/x/net/context"
 )
@@ -36,6 +37,7 @@ type mysqlInstance struct {
 type Checker struct {
 	closed sync2.AtomicBool
 
+	taskName  string
 	instances []*mysqlInstance
 
 	checkList []check.Checker
@@ -43,12 +45,15 @@ type Checker struct {
 		sync.RWMutex
 		detail *check.Results
 	}
+
+	metricsRegistry prometheus.Registerer
 }
 
 // NewChecker returns a checker
 func NewChecker(cfgs []*config.SubTaskConfig) *Checker {
 	c := &Checker{
-		instances: make([]*mysqlInstance, 0, len(cfgs)),
+		instances:       make([]*mysqlInstance, 0, len(cfgs)),
+		metricsRegistry: prometheus.DefaultRegisterer,
 	}
 
 	for _, cfg := range cfgs {
@@ -56,12 +61,27 @@ func NewChecker(cfgs []*config.SubTaskConfig) *Checker {
 			cfg: cfg,
 		})
 	}
+	if len(cfgs) > 0 {
+		c.taskName = cfgs[0].Name
+	}
+
+	return c
+}
 
+// WithMetricsRegistry makes the Checker register its Prometheus metrics on
+// registry instead of the global default. The collectors themselves are
+// package-level and shared by every Checker: each registry they are
+// registered on exposes the same values, one label set per task, and
+// resetStaleMetrics clears a task's label sets from all of them at once.
+func (c *Checker) WithMetricsRegistry(registry prometheus.Registerer) *Checker {
+	c.metricsRegistry = registry
 	return c
 }
 
 // Init implements Unit interface
 func (c *Checker) Init() error {
+	resetStaleMetrics(c.taskName)
+	RegisterMetrics(c.metricsRegistry)
 
 	// target name => instance => schema => [tables]
 	sharding := make(map[string]map[string]map[string][]string)
@@ -127,6 +147,12 @@ func (c *Checker) Init() error {
 		c.checkList = append(c.checkList, check.NewMySQLBinlogRowImageChecker(instance.sourceDB, instance.sourceDBinfo))
 		c.checkList = append(c.checkList, check.NewSourcePrivilegeChecker(instance.sourceDB, instance.sourceDBinfo))
 		c.checkList = append(c.checkList, check.NewTablesChecker(instance.sourceDB, instance.sourceDBinfo, checkTables))
+
+		if !instance.cfg.SkipTargetTableCompatibilityCheck {
+			c.checkList = append(c.checkList, NewTargetTableCompatibilityChecker(
+				instance.sourceDB, instance.targetDB, instance.sourceDBinfo, instance.targetDBInfo,
+				checkTables, instance.cfg.AutoCreateTable))
+		}
 	}
 
 	for name, shardingSet := range sharding {
@@ -137,6 +163,10 @@ func (c *Checker) Init() error {
 		c.checkList = append(c.checkList, check.NewShardingTablesCheck(name, dbs, shardingSet))
 	}
 
+	for i, checker := range c.checkList {
+		c.checkList[i] = withTiming(c.taskName, checker)
+	}
+
 	return nil
 }
 
@@ -168,6 +198,10 @@ func (c *Checker) Process(ctx context.Context, pr chan pb.ProcessResult) {
 	c.result.detail = result
 	c.result.Unlock()
 
+	checkerTotalGauge.WithLabelValues(c.taskName).Set(float64(result.Summary.Total))
+	checkerFailedGauge.WithLabelValues(c.taskName).Set(float64(result.Summary.Failed))
+	checkerWarningGauge.WithLabelValues(c.taskName).Set(float64(result.Summary.Warning))
+
 	pr <- pb.ProcessResult{
 		IsCanceled: isCanceled,
 		Errors:     errs,
@@ -181,6 +215,8 @@ func (c *Checker) Close() {
 		return
 	}
 
+	resetStaleMetrics(c.taskName)
+
 	for _, instance := range c.instances {
 		if instance.sourceDB != nil {
 			if err := dbutil.CloseDB(instance.sourceDB); err != nil {
@@ -204,6 +240,10 @@ func (c *Checker) Pause() {
 		log.Warn("[checker] try to pause, but already closed")
 		return
 	}
+
+	// a paused checker has no current result, so don't let its last gauges
+	// (e.g. "failed") keep misleading operators until Resume runs again.
+	resetStaleMetrics(c.taskName)
 }
 
 // Resume resumes the paused process
@@ -213,6 +253,8 @@ func (c *Checker) Resume(ctx context.Context, pr chan pb.ProcessResult) {
 		return
 	}
 
+	resetStaleMetrics(c.taskName)
+	RegisterMetrics(c.metricsRegistry)
 	c.Process(ctx, pr)
 }
 
@@ -0,0 +1,114 @@
+package checker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb-tools/pkg/check"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	checkerTotalGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "dm",
+		Subsystem: "checker",
+		Name:      "total",
+		Help:      "Total number of checks run for a task's last pre-check.",
+	}, []string{"task"})
+
+	checkerFailedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "dm",
+		Subsystem: "checker",
+		Name:      "failed",
+		Help:      "Number of failed checks in a task's last pre-check.",
+	}, []string{"task"})
+
+	checkerWarningGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "dm",
+		Subsystem: "checker",
+		Name:      "warning",
+		Help:      "Number of checks that passed with a warning in a task's last pre-check.",
+	}, []string{"task"})
+
+	checkerLatencyHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "dm",
+		Subsystem: "checker",
+		Name:      "check_duration_seconds",
+		Help:      "Latency of an individual check, by task and check name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"task", "check"})
+)
+
+// checkNamesSeen remembers, per task, which check names resetStaleMetrics
+// needs to clear out of checkerLatencyHistogram before the next run
+// repopulates it; the gauges above carry no per-check label so a plain
+// Delete(task) is enough for them, but the histogram's label set can't be
+// cleared without knowing which check names were used last time.
+var checkNamesSeen = struct {
+	sync.Mutex
+	byTask map[string][]string
+}{byTask: make(map[string][]string)}
+
+// RegisterMetrics registers this package's collectors on registry. Call it
+// once per process using the default Checker(s); embedders that want the
+// metrics exposed on a registry of their own should use WithMetricsRegistry
+// instead. Safe to call
+// more than once on the same registry, since Checker.Init calls it every
+// time a checker is (re-)initialized.
+func RegisterMetrics(registry prometheus.Registerer) {
+	for _, c := range []prometheus.Collector{checkerTotalGauge, checkerFailedGauge, checkerWarningGauge, checkerLatencyHistogram} {
+		if err := registry.Register(c); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				log.Errorf("[checker] failed to register metric: %v", err)
+			}
+		}
+	}
+}
+
+// resetStaleMetrics clears every metric this package publishes for
+// taskName, so a checker restarted after a crash doesn't leave a previous
+// run's "failed" gauge stuck high. Mirrors the defensive reset tiflow's
+// owner does before re-registering per-task metrics.
+func resetStaleMetrics(taskName string) {
+	checkerTotalGauge.DeleteLabelValues(taskName)
+	checkerFailedGauge.DeleteLabelValues(taskName)
+	checkerWarningGauge.DeleteLabelValues(taskName)
+
+	checkNamesSeen.Lock()
+	for _, name := range checkNamesSeen.byTask[taskName] {
+		checkerLatencyHistogram.DeleteLabelValues(taskName, name)
+	}
+	delete(checkNamesSeen.byTask, taskName)
+	checkNamesSeen.Unlock()
+}
+
+// observeCheckLatency records how long a single check took, and remembers
+// its name so a future resetStaleMetrics can find it again.
+func observeCheckLatency(taskName, checkName string, seconds float64) {
+	checkerLatencyHistogram.WithLabelValues(taskName, checkName).Observe(seconds)
+
+	checkNamesSeen.Lock()
+	checkNamesSeen.byTask[taskName] = append(checkNamesSeen.byTask[taskName], checkName)
+	checkNamesSeen.Unlock()
+}
+
+// timingChecker wraps a check.Checker so every Check call is timed and fed
+// into checkerLatencyHistogram under the wrapped checker's own name.
+type timingChecker struct {
+	check.Checker
+	taskName string
+}
+
+func withTiming(taskName string, c check.Checker) check.Checker {
+	return &timingChecker{Checker: c, taskName: taskName}
+}
+
+// Check implements check.Checker.
+func (t *timingChecker) Check(ctx context.Context) *check.Result {
+	start := time.Now()
+	result := t.Checker.Check(ctx)
+	observeCheckLatency(t.taskName, t.Checker.Name(), time.Since(start).Seconds())
+	return result
+}
@@ -0,0 +1,93 @@
+package checker
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/juju/errors"
+)
+
+// showCreateTable returns a table's columns (name -> data type, as reported
+// by information_schema), each character column's collation (name ->
+// collation, omitted for non-character columns), and its unique/primary key
+// column sets, ordered within each key by key position.
+func showCreateTable(ctx context.Context, db *sql.DB, schema, table string) (map[string]string, map[string]string, [][]string, error) {
+	cols, collations, err := fetchColumnTypes(ctx, db, schema, table)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+
+	keys, err := fetchUniqueKeys(ctx, db, schema, table)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+
+	return cols, collations, keys, nil
+}
+
+func fetchColumnTypes(ctx context.Context, db *sql.DB, schema, table string) (map[string]string, map[string]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT COLUMN_NAME, DATA_TYPE, COLLATION_NAME
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`, schema, table)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	defer rows.Close()
+
+	cols := make(map[string]string)
+	collations := make(map[string]string)
+	for rows.Next() {
+		var name, dataType string
+		var collation sql.NullString
+		if err := rows.Scan(&name, &dataType, &collation); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		cols[name] = dataType
+		if collation.Valid {
+			collations[name] = collation.String
+		}
+	}
+
+	if len(cols) == 0 {
+		return nil, nil, errors.Errorf("table `%s`.`%s` doesn't exist", schema, table)
+	}
+
+	return cols, collations, errors.Trace(rows.Err())
+}
+
+func fetchUniqueKeys(ctx context.Context, db *sql.DB, schema, table string) ([][]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT INDEX_NAME, COLUMN_NAME
+		FROM INFORMATION_SCHEMA.STATISTICS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND NON_UNIQUE = 0
+		ORDER BY INDEX_NAME, SEQ_IN_INDEX`, schema, table)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer rows.Close()
+
+	var (
+		keys    [][]string
+		curName string
+		cur     []string
+	)
+	for rows.Next() {
+		var indexName, columnName string
+		if err := rows.Scan(&indexName, &columnName); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if indexName != curName {
+			if len(cur) > 0 {
+				keys = append(keys, cur)
+			}
+			curName, cur = indexName, nil
+		}
+		cur = append(cur, columnName)
+	}
+	if len(cur) > 0 {
+		keys = append(keys, cur)
+	}
+
+	return keys, errors.Trace(rows.Err())
+}
@@ -0,0 +1,217 @@
+package checker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb-tools/pkg/check"
+	"github.com/pingcap/tidb-tools/pkg/dbutil"
+)
+
+// targetTableCompatibilityChecker verifies that a source table can actually
+// be replicated into its routed target table: the target exists (or the
+// task is allowed to create it), every source column is present on the
+// target with a compatible type, the target's key set covers the source's,
+// and charset/collation match. Unlike the other checkers in this package it
+// looks at the target side, not just the source.
+type targetTableCompatibilityChecker struct {
+	sourceDB     *sql.DB
+	sourceDBInfo *dbutil.DBConfig
+	targetDB     *sql.DB
+	targetDBInfo *dbutil.DBConfig
+
+	// schema -> tables, as produced by utils.FetchTargetDoTables after
+	// route rules have been applied.
+	checkTables map[string][]string
+
+	// autoCreate mirrors the task's "create target tables automatically"
+	// setting: a missing target table is a warning instead of a failure.
+	autoCreate bool
+}
+
+// NewTargetTableCompatibilityChecker returns a Checker that compares every
+// `(schema, table)` pair against what already exists on the target.
+func NewTargetTableCompatibilityChecker(sourceDB, targetDB *sql.DB, sourceDBInfo, targetDBInfo *dbutil.DBConfig, checkTables map[string][]string, autoCreate bool) check.Checker {
+	return &targetTableCompatibilityChecker{
+		sourceDB:     sourceDB,
+		sourceDBInfo: sourceDBInfo,
+		targetDB:     targetDB,
+		targetDBInfo: targetDBInfo,
+		checkTables:  checkTables,
+		autoCreate:   autoCreate,
+	}
+}
+
+// widenings records column type pairs MySQL can implicitly convert between
+// without truncation risk; anything not listed here is reported as an error
+// rather than a warning.
+var widenings = map[string][]string{
+	"tinyint":   {"smallint", "mediumint", "int", "bigint"},
+	"smallint":  {"mediumint", "int", "bigint"},
+	"mediumint": {"int", "bigint"},
+	"int":       {"bigint"},
+	"char":      {"varchar"},
+	"varchar":   {"text", "mediumtext", "longtext"},
+}
+
+// Check implements the check.Checker interface.
+func (c *targetTableCompatibilityChecker) Check(ctx context.Context) *check.Result {
+	r := &check.Result{
+		Name:  c.Name(),
+		Desc:  "check that source tables are compatible with what exists on the target",
+		State: check.StateSuccess,
+	}
+
+	for schema, tables := range c.checkTables {
+		for _, table := range tables {
+			if err := c.checkTable(ctx, r, schema, table); err != nil {
+				markError(r, err)
+			}
+		}
+	}
+
+	return r
+}
+
+// checkTable compares one source/target table pair and records every issue
+// it finds on r, rather than stopping at the first one, so dmctl can render
+// a complete per-table diff instead of a single symptom.
+func (c *targetTableCompatibilityChecker) checkTable(ctx context.Context, r *check.Result, schema, table string) error {
+	sourceCols, sourceCollations, sourceKeys, err := showCreateTable(ctx, c.sourceDB, schema, table)
+	if err != nil {
+		return errors.Annotatef(err, "show create table `%s`.`%s` on source", schema, table)
+	}
+
+	targetCols, targetCollations, targetKeys, err := showCreateTable(ctx, c.targetDB, schema, table)
+	if err != nil {
+		if isTableNotExist(err) {
+			if c.autoCreate {
+				markWarning(r, fmt.Sprintf("target table `%s`.`%s` doesn't exist yet, will be auto-created", schema, table))
+				return nil
+			}
+			return errors.Errorf("target table `%s`.`%s` doesn't exist and auto-create is disabled", schema, table)
+		}
+		return errors.Annotatef(err, "show create table `%s`.`%s` on target", schema, table)
+	}
+
+	for name, sourceType := range sourceCols {
+		targetType, ok := targetCols[name]
+		if !ok {
+			markError(r, errors.Errorf("column `%s` of `%s`.`%s` is missing on target", name, schema, table))
+			continue
+		}
+		if sourceType != targetType {
+			if isWideningConversion(sourceType, targetType) {
+				markWarning(r, fmt.Sprintf("column `%s` of `%s`.`%s` widens from %s to %s", name, schema, table, sourceType, targetType))
+			} else {
+				markError(r, errors.Errorf("column `%s` of `%s`.`%s` has incompatible type: source %s, target %s", name, schema, table, sourceType, targetType))
+			}
+		}
+
+		checkCollation(r, schema, table, name, sourceCollations[name], targetCollations[name])
+	}
+
+	for _, key := range sourceKeys {
+		if !containsAll(targetKeys, key) {
+			markError(r, errors.Errorf("no primary/unique key of `%s`.`%s` on target is a subset of source key %v", schema, table, key))
+		}
+	}
+
+	return nil
+}
+
+// checkCollation compares a character column's collation between source and
+// target. A charset mismatch (the part of the collation name before its
+// first underscore, e.g. "utf8mb4" in "utf8mb4_general_ci") can silently
+// corrupt or reject data on replication, so it's an error; a same-charset
+// collation mismatch only affects comparison/sort order, so it's a warning.
+// Non-character columns (no collation on either side) are skipped.
+func checkCollation(r *check.Result, schema, table, column, sourceCollation, targetCollation string) {
+	if sourceCollation == "" && targetCollation == "" {
+		return
+	}
+	if sourceCollation == targetCollation {
+		return
+	}
+
+	if charsetOf(sourceCollation) != charsetOf(targetCollation) {
+		markError(r, errors.Errorf("column `%s` of `%s`.`%s` has incompatible charset: source %s, target %s",
+			column, schema, table, sourceCollation, targetCollation))
+		return
+	}
+
+	markWarning(r, fmt.Sprintf("column `%s` of `%s`.`%s` collation differs: source %s, target %s",
+		column, schema, table, sourceCollation, targetCollation))
+}
+
+// charsetOf extracts the charset prefix from a collation name, e.g.
+// "utf8mb4" from "utf8mb4_general_ci".
+func charsetOf(collation string) string {
+	if idx := strings.Index(collation, "_"); idx >= 0 {
+		return collation[:idx]
+	}
+	return collation
+}
+
+// Name implements the check.Checker interface.
+func (c *targetTableCompatibilityChecker) Name() string {
+	return "target table compatibility"
+}
+
+func isWideningConversion(from, to string) bool {
+	for _, allowed := range widenings[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAll reports whether want (a source key) has a target key among
+// keys that is a subset of it: every column of that target key is also in
+// want, so a query built from want's columns has enough to use the target
+// key too.
+func containsAll(keys [][]string, want []string) bool {
+	for _, key := range keys {
+		if sameColumnSet(want, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameColumnSet reports whether b is a subset of a's columns.
+func sameColumnSet(a, b []string) bool {
+	if len(a) < len(b) {
+		return false
+	}
+	set := make(map[string]struct{}, len(a))
+	for _, col := range a {
+		set[col] = struct{}{}
+	}
+	for _, col := range b {
+		if _, ok := set[col]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func isTableNotExist(err error) bool {
+	return strings.Contains(errors.Cause(err).Error(), "doesn't exist")
+}
+
+func markWarning(r *check.Result, msg string) {
+	if r.State == check.StateSuccess {
+		r.State = check.StateWarning
+	}
+	r.Extra += msg + "\n"
+}
+
+func markError(r *check.Result, err error) {
+	r.State = check.StateFailure
+	r.Errors = append(r.Errors, check.NewError(err))
+}
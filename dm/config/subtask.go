@@ -0,0 +1,60 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config holds the configuration of a single sub-task: one
+// source-to-target replication pipeline managed by the checker and syncer
+// units.
+package config
+
+import (
+	"github.com/pingcap/tidb-enterprise-tools/pkg/filter"
+	router "github.com/pingcap/tidb-tools/pkg/table-router"
+)
+
+// DBConfig is the connection info for one side (source or target) of a
+// sub-task.
+type DBConfig struct {
+	Host     string `toml:"host" json:"host" yaml:"host"`
+	Port     int    `toml:"port" json:"port" yaml:"port"`
+	User     string `toml:"user" json:"user" yaml:"user"`
+	Password string `toml:"password" json:"password" yaml:"password"`
+}
+
+// SubTaskConfig is the configuration of a single sub-task.
+type SubTaskConfig struct {
+	Name string `toml:"name" json:"name" yaml:"name"`
+
+	From DBConfig `toml:"from" json:"from" yaml:"from"`
+	To   DBConfig `toml:"to" json:"to" yaml:"to"`
+
+	BWList     *filter.Rules       `toml:"bw-list" json:"bw-list" yaml:"bw-list"`
+	RouteRules []*router.TableRule `toml:"route-rules" json:"route-rules" yaml:"route-rules"`
+
+	// MetaDir is where a task persists state (checkpoints, schema tracker
+	// snapshots) that should survive a restart. An empty MetaDir means
+	// nothing is persisted to disk.
+	MetaDir string `toml:"meta-dir" json:"meta-dir" yaml:"meta-dir"`
+
+	// DiscardNoMetaRowEvent makes the syncer drop row events for tables the
+	// schema tracker has no schema for, instead of failing the sub-task.
+	DiscardNoMetaRowEvent bool `toml:"discard-no-meta-row-event" json:"discard-no-meta-row-event" yaml:"discard-no-meta-row-event"`
+
+	// SkipTargetTableCompatibilityCheck skips comparing source and target
+	// table schemas during pre-flight checks, for tasks where the target is
+	// known to already be compatible (or is about to be created fresh).
+	SkipTargetTableCompatibilityCheck bool `toml:"skip-target-table-compatibility-check" json:"skip-target-table-compatibility-check" yaml:"skip-target-table-compatibility-check"`
+
+	// AutoCreateTable lets the task create missing target tables itself
+	// instead of treating them as a pre-flight check failure.
+	AutoCreateTable bool `toml:"auto-create-table" json:"auto-create-table" yaml:"auto-create-table"`
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/ngaut/log"
 )
 
 // Table represents a table.
@@ -24,6 +26,14 @@ type Rules struct {
 
 	IgnoreTables []*Table `json:"ignore-tables" yaml:"ignore-tables"`
 	IgnoreDBs    []string `json:"ignore-dbs" yaml:"ignore-dbs"`
+
+	// DoWildTables and IgnoreWildTables hold qualified `db.tbl` patterns in
+	// MySQL's own replicate-wild-do-table / replicate-wild-ignore-table
+	// syntax: `_` matches a single character, `%` matches any run of
+	// characters, and a leading `~` switches the whole `` `db`.`tbl` ``
+	// string to regex matching, same as the per-field rules above.
+	DoWildTables     []string `json:"do-wild-tables" yaml:"do-wild-tables"`
+	IgnoreWildTables []string `json:"ignore-wild-tables" yaml:"ignore-wild-tables"`
 }
 
 // ToLower convert all entries to lowercase
@@ -52,17 +62,108 @@ func (r *Rules) ToLower() {
 type Filter struct {
 	patternMap map[string]*regexp.Regexp
 	rules      *Rules
+
+	doWildTables     []*regexp.Regexp
+	ignoreWildTables []*regexp.Regexp
 }
 
 // New creates a filter use the rules.
 func New(rules *Rules) *Filter {
+	migrateDeprecatedWildcards(rules)
+
 	f := &Filter{}
 	f.rules = rules
 	f.patternMap = make(map[string]*regexp.Regexp)
 	f.genRegexMap()
+	f.doWildTables = compileWildTables(rules.doWildTablesOf())
+	f.ignoreWildTables = compileWildTables(rules.ignoreWildTablesOf())
 	return f
 }
 
+// doWildTablesOf and ignoreWildTablesOf exist only so New can treat a nil
+// Rules the same way the rest of the package does.
+func (r *Rules) doWildTablesOf() []string {
+	if r == nil {
+		return nil
+	}
+	return r.DoWildTables
+}
+
+func (r *Rules) ignoreWildTablesOf() []string {
+	if r == nil {
+		return nil
+	}
+	return r.IgnoreWildTables
+}
+
+// compileWildTables compiles each `db.tbl` qualified pattern once: `~`
+// switches to plain regex matched against `` `db`.`tbl` ``, otherwise `%`
+// becomes `.*`, `_` becomes `.`, and everything else is escaped before the
+// whole pattern is anchored.
+func compileWildTables(patterns []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		res = append(res, compileWildTable(pattern))
+	}
+	return res
+}
+
+func compileWildTable(pattern string) *regexp.Regexp {
+	if len(pattern) > 0 && pattern[0] == '~' {
+		return regexp.MustCompile(fmt.Sprintf("(?i)%s", pattern[1:]))
+	}
+
+	escaped := regexp.QuoteMeta(pattern)
+	escaped = strings.Replace(escaped, "%", ".*", -1)
+	escaped = strings.Replace(escaped, "_", ".", -1)
+	return regexp.MustCompile(fmt.Sprintf("(?i)^%s$", escaped))
+}
+
+// wildSubject is the string a qualified wild-table pattern is matched
+// against. The `~` regex form matches the backtick-quoted form so it can
+// reuse the same idiom as the per-field `~` rules; the `%`/`_` form matches
+// the plain `db.tbl` form since that's what the pattern itself looks like.
+func wildSubject(pattern string, tb *Table) string {
+	if len(pattern) > 0 && pattern[0] == '~' {
+		return tb.String()
+	}
+	return tb.Schema + "." + tb.Name
+}
+
+// migrateDeprecatedWildcards moves any do-tables/ignore-tables entry that
+// contains a bare (non-`~`) `%` or `_` into the new qualified wildcard
+// fields, since that was never valid syntax for the per-field matcher and
+// operators writing MySQL-style wildcards there got lucky rather than
+// getting what they asked for.
+func migrateDeprecatedWildcards(rules *Rules) {
+	if rules == nil {
+		return
+	}
+
+	rules.DoTables, rules.DoWildTables = migrateWildcardTables(rules.DoTables, rules.DoWildTables)
+	rules.IgnoreTables, rules.IgnoreWildTables = migrateWildcardTables(rules.IgnoreTables, rules.IgnoreWildTables)
+}
+
+func migrateWildcardTables(tables []*Table, wild []string) ([]*Table, []string) {
+	kept := tables[:0]
+	for _, tb := range tables {
+		if isDeprecatedWildcard(tb.Schema) || isDeprecatedWildcard(tb.Name) {
+			log.Warnf("[filter] do-tables/ignore-tables entry `%s`.`%s` uses MySQL wildcard syntax in a field meant for exact names or `~regex`; migrating it to a qualified wild-table rule, please update your config", tb.Schema, tb.Name)
+			wild = append(wild, tb.Schema+"."+tb.Name)
+			continue
+		}
+		kept = append(kept, tb)
+	}
+	return kept, wild
+}
+
+func isDeprecatedWildcard(s string) bool {
+	if len(s) == 0 || s[0] == '~' {
+		return false
+	}
+	return strings.ContainsAny(s, "%_")
+}
+
 func (f *Filter) genRegexMap() {
 	if f.rules == nil {
 		return
@@ -148,19 +249,30 @@ func (f *Filter) filterOnTables(tb *Table) bool {
 		return true
 	}
 
-	if len(f.rules.DoTables) > 0 {
-		if f.findMatchedDoTables(tb) {
-			return true
-		}
+	if len(f.rules.DoTables) > 0 && f.findMatchedDoTables(tb) {
+		return true
+	}
+	if len(f.rules.DoWildTables) > 0 && f.findMatchedWildTables(f.doWildTables, f.rules.DoWildTables, tb) {
+		return true
 	}
 
-	if len(f.rules.IgnoreTables) > 0 {
-		if f.findMatchedIgnoreTables(tb) {
-			return false
-		}
+	if len(f.rules.IgnoreTables) > 0 && f.findMatchedIgnoreTables(tb) {
+		return false
+	}
+	if len(f.rules.IgnoreWildTables) > 0 && f.findMatchedWildTables(f.ignoreWildTables, f.rules.IgnoreWildTables, tb) {
+		return false
 	}
 
-	return len(f.rules.DoTables) == 0
+	return len(f.rules.DoTables) == 0 && len(f.rules.DoWildTables) == 0
+}
+
+func (f *Filter) findMatchedWildTables(compiled []*regexp.Regexp, patterns []string, tb *Table) bool {
+	for i, re := range compiled {
+		if re.MatchString(wildSubject(patterns[i], tb)) {
+			return true
+		}
+	}
+	return false
 }
 
 func (f *Filter) findMatchedDoTables(tb *Table) bool {
@@ -195,4 +307,22 @@ func (f *Filter) matchString(pattern string, t string) bool {
 		return re.MatchString(t)
 	}
 	return pattern == t
+}
+
+// Match reports whether schema.table passes the filter, and if it doesn't,
+// a human-readable reason a caller can log instead of silently dropping the
+// row.
+func (f *Filter) Match(schema, table string) (bool, string) {
+	if f == nil || f.rules == nil {
+		return true, ""
+	}
+
+	tb := &Table{Schema: schema, Name: table}
+	if !f.filterOnSchemas(tb) {
+		return false, fmt.Sprintf("schema `%s` doesn't match do-dbs/ignore-dbs rules", schema)
+	}
+	if !f.filterOnTables(tb) {
+		return false, fmt.Sprintf("table `%s`.`%s` doesn't match do-tables/ignore-tables/do-wild-tables/ignore-wild-tables rules", schema, table)
+	}
+	return true, ""
 }
\ No newline at end of file
@@ -0,0 +1,89 @@
+package filter
+
+import "testing"
+
+func TestCompileWildTable(t *testing.T) {
+	cases := []struct {
+		pattern string
+		subject string
+		match   bool
+	}{
+		{"test%.tbl_", "test1.tbl1", true},
+		{"test%.tbl_", "test1.tbl12", false},
+		{"test_.tbl%", "testa.tblxyz", true},
+		{"test_.tbl%", "testab.tblxyz", false},
+		{"~^`test.*`\\.`tbl`$", "`test1`.`tbl`", true},
+		{"~^`test.*`\\.`tbl`$", "`test1`.`tbl1`", false},
+	}
+
+	for _, c := range cases {
+		re := compileWildTable(c.pattern)
+		if got := re.MatchString(c.subject); got != c.match {
+			t.Errorf("compileWildTable(%q).MatchString(%q) = %v, want %v", c.pattern, c.subject, got, c.match)
+		}
+	}
+}
+
+func TestWildSubject(t *testing.T) {
+	tb := &Table{Schema: "test", Name: "tbl"}
+
+	if got, want := wildSubject("test%.tbl_", tb), "test.tbl"; got != want {
+		t.Errorf("wildSubject(%%/_ pattern) = %q, want %q", got, want)
+	}
+	if got, want := wildSubject("~^test\\..*$", tb), "`test`.`tbl`"; got != want {
+		t.Errorf("wildSubject(~ pattern) = %q, want %q", got, want)
+	}
+}
+
+func TestMigrateWildcardTables(t *testing.T) {
+	tables := []*Table{
+		{Schema: "test", Name: "tbl1"},
+		{Schema: "test%", Name: "tbl_"},
+		{Schema: "~^shard", Name: "tbl2"},
+	}
+
+	kept, wild := migrateWildcardTables(tables, nil)
+
+	if len(kept) != 2 {
+		t.Fatalf("expected 2 tables kept, got %d: %v", len(kept), kept)
+	}
+	if kept[0].Schema != "test" || kept[0].Name != "tbl1" {
+		t.Errorf("expected first kept table to be test.tbl1, got %v", kept[0])
+	}
+	if kept[1].Schema != "~^shard" || kept[1].Name != "tbl2" {
+		t.Errorf("expected ~regex table to be kept as-is, got %v", kept[1])
+	}
+
+	if len(wild) != 1 || wild[0] != "test%.tbl_" {
+		t.Fatalf("expected migrated wild-table rule \"test%%.tbl_\", got %v", wild)
+	}
+}
+
+func TestFilterDoWildTables(t *testing.T) {
+	f := New(&Rules{
+		DoWildTables: []string{"test%.tbl_"},
+	})
+
+	tbs := f.ApplyOn([]*Table{
+		{Schema: "test1", Name: "tbl1"},
+		{Schema: "test1", Name: "tbl12"},
+		{Schema: "other", Name: "tbl1"},
+	})
+
+	if len(tbs) != 1 || tbs[0].Schema != "test1" || tbs[0].Name != "tbl1" {
+		t.Fatalf("expected only test1.tbl1 to pass, got %v", tbs)
+	}
+}
+
+func TestFilterMigratesDeprecatedWildcardSyntax(t *testing.T) {
+	rules := &Rules{
+		DoTables: []*Table{{Schema: "test%", Name: "tbl_"}},
+	}
+
+	f := New(rules)
+
+	matched, reason := f.Match("test1", "tbl1")
+	if !matched {
+		t.Fatalf("expected migrated wildcard rule to match, got reason: %s", reason)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// MemoryStorage keeps Tracker snapshots in process memory. Every task that
+// uses it starts cold on restart, which is fine for tests and for tasks
+// that don't mind re-dumping schema from the source.
+type MemoryStorage struct {
+	mu   sync.Mutex
+	snap map[string]*Snapshot
+}
+
+// NewMemoryStorage returns a Storage backed by a plain map.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{snap: make(map[string]*Snapshot)}
+}
+
+// Load implements Storage.
+func (m *MemoryStorage) Load(taskName string) (*Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snap[taskName], nil
+}
+
+// Save implements Storage.
+func (m *MemoryStorage) Save(taskName string, snap *Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snap[taskName] = snap
+	return nil
+}
+
+// FileStorage persists a Tracker snapshot as one JSON file per task under
+// dir, so a restart restores schema without re-dumping SHOW CREATE TABLE.
+type FileStorage struct {
+	dir string
+}
+
+// NewFileStorage returns a Storage that keeps one snapshot file per task
+// under dir, creating dir if it doesn't already exist.
+func NewFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &FileStorage{dir: dir}, nil
+}
+
+// Load implements Storage.
+func (f *FileStorage) Load(taskName string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(f.path(taskName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	snap := &Snapshot{}
+	if err := json.Unmarshal(data, snap); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return snap, nil
+}
+
+// Save implements Storage.
+func (f *FileStorage) Save(taskName string, snap *Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	tmp := f.path(taskName) + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(tmp, f.path(taskName)))
+}
+
+func (f *FileStorage) path(taskName string) string {
+	return filepath.Join(f.dir, taskName+".schema.json")
+}
@@ -0,0 +1,384 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schema maintains an in-process view of every replicated schema so
+// that DML row events can be resolved without going back to the live source.
+package schema
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb-enterprise-tools/pkg/filter"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/parser"
+	"github.com/siddontang/go-mysql/mysql"
+)
+
+// TableInfo is the tracker's lightweight view of a table: just enough to
+// resolve column names for row events, not a full TiDB infoschema TableInfo.
+type TableInfo struct {
+	Schema  string
+	Name    string
+	Columns []string
+}
+
+// Snapshot is the persisted state of a Tracker: the schema it has built up
+// plus the binlog position it corresponds to, so a restart can resume
+// without re-dumping every table.
+type Snapshot struct {
+	Position mysql.Position
+	Tables   []*TableInfo
+}
+
+// Storage persists and restores Tracker snapshots. The in-memory
+// implementation is used by tests and by tasks that accept re-dumping on
+// restart; FileStorage is the production choice.
+type Storage interface {
+	// Load returns nil, nil if no snapshot exists yet for taskName.
+	Load(taskName string) (*Snapshot, error)
+	Save(taskName string, snap *Snapshot) error
+}
+
+// Tracker keeps an in-process representation of every replicated schema,
+// built from SHOW CREATE TABLE at startup and kept current by feeding it
+// every DDL the syncer decides to apply.
+type Tracker struct {
+	taskName string
+	storage  Storage
+	parser   *parser.Parser
+
+	// db is the source connection Bootstrap was called with, kept around so
+	// Exec can dump a table on demand for `CREATE TABLE ... LIKE` statements
+	// that reference a table the tracker hasn't seen yet.
+	db *sql.DB
+
+	mu       sync.RWMutex
+	schemas  map[string]map[string]*TableInfo // schema -> table -> info
+	position mysql.Position
+
+	unknown *unknownTables
+}
+
+// NewTracker creates a Tracker for the given task, restoring a previous
+// snapshot from storage if one exists.
+func NewTracker(taskName string, storage Storage) (*Tracker, error) {
+	t := &Tracker{
+		taskName: taskName,
+		storage:  storage,
+		parser:   parser.New(),
+		schemas:  make(map[string]map[string]*TableInfo),
+		unknown:  newUnknownTables(),
+	}
+
+	snap, err := storage.Load(taskName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if snap != nil {
+		t.position = snap.Position
+		for _, tbl := range snap.Tables {
+			t.setTable(tbl)
+		}
+	}
+
+	return t, nil
+}
+
+// Bootstrap seeds the tracker by dumping SHOW CREATE TABLE for every table
+// that survives bw.ApplyOn. It's a no-op for tables the tracker already
+// knows about, so a restart that found a snapshot won't re-dump.
+func (t *Tracker) Bootstrap(db *sql.DB, tables []*filter.Table, bw *filter.Filter) error {
+	t.db = db
+
+	for _, tb := range bw.ApplyOn(tables) {
+		if _, ok := t.GetTable(tb.Schema, tb.Name); ok {
+			continue
+		}
+
+		info, err := t.dumpTable(tb.Schema, tb.Name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		t.setTable(info)
+	}
+
+	return nil
+}
+
+// dumpTable fetches a table's current columns straight from the source via
+// SHOW CREATE TABLE. Used both by Bootstrap and, lazily, by Exec when a
+// `CREATE TABLE ... LIKE` references a table the tracker hasn't dumped yet.
+func (t *Tracker) dumpTable(schema, name string) (*TableInfo, error) {
+	if t.db == nil {
+		return nil, errors.Errorf("no source connection available to dump `%s`.`%s`", schema, name)
+	}
+
+	tb := &filter.Table{Schema: schema, Name: name}
+	var tableName, createSQL string
+	query := "SHOW CREATE TABLE " + tb.String()
+	row := t.db.QueryRow(query)
+	if err := row.Scan(&tableName, &createSQL); err != nil {
+		return nil, errors.Annotatef(err, "dump schema for %s", tb)
+	}
+
+	stmt, err := t.parser.ParseOneStmt(createSQL, "", "")
+	if err != nil {
+		return nil, errors.Annotatef(err, "parse create table for %s", tb)
+	}
+	create, ok := stmt.(*ast.CreateTableStmt)
+	if !ok {
+		return nil, errors.Errorf("expect CREATE TABLE statement for %s, got %T", tb, stmt)
+	}
+
+	return &TableInfo{Schema: schema, Name: name, Columns: columnsOf(create.Cols)}, nil
+}
+
+// Exec applies a parsed DDL statement to the tracker's schema state. It's
+// meant to be called with the same statement that already passed
+// skipQuery/skipDDLEvent, after schema defaults to defaultSchema for
+// statements (like CREATE TABLE without a qualifier) that don't carry one.
+func (t *Tracker) Exec(defaultSchema string, stmt ast.StmtNode) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch v := stmt.(type) {
+	case *ast.CreateDatabaseStmt:
+		t.createSchema(v.Name)
+	case *ast.DropDatabaseStmt:
+		delete(t.schemas, v.Name)
+	case *ast.CreateTableStmt:
+		schema, name := qualify(defaultSchema, v.Table)
+		if v.ReferTable != nil {
+			columns, err := t.likeColumnsLocked(defaultSchema, v.ReferTable)
+			if err != nil {
+				return errors.Annotatef(err, "resolve CREATE TABLE %s LIKE %s", v.Table.Name.O, v.ReferTable.Name.O)
+			}
+			t.setTableLocked(&TableInfo{Schema: schema, Name: name, Columns: columns})
+		} else {
+			t.setTableLocked(&TableInfo{Schema: schema, Name: name, Columns: columnsOf(v.Cols)})
+		}
+	case *ast.DropTableStmt:
+		for _, tbl := range v.Tables {
+			schema, name := qualify(defaultSchema, tbl)
+			t.dropTableLocked(schema, name)
+		}
+	case *ast.RenameTableStmt:
+		for _, clause := range v.TableToTables {
+			oldSchema, oldName := qualify(defaultSchema, clause.OldTable)
+			newSchema, newName := qualify(defaultSchema, clause.NewTable)
+			info, ok := t.tableLocked(oldSchema, oldName)
+			if !ok {
+				continue
+			}
+			t.dropTableLocked(oldSchema, oldName)
+			t.setTableLocked(&TableInfo{Schema: newSchema, Name: newName, Columns: info.Columns})
+		}
+	case *ast.AlterTableStmt:
+		schema, name := qualify(defaultSchema, v.Table)
+		info, ok := t.tableLocked(schema, name)
+		if !ok {
+			// Unknown table: nothing to keep in sync, let the caller decide
+			// whether to treat this as a DiscardNoMetaRowEvent case.
+			return nil
+		}
+		for _, spec := range v.Specs {
+			applyAlterSpec(info, spec)
+			if spec.Tp == ast.AlterTableRenameTable {
+				// applyAlterSpec only updated info's own Schema/Name; the
+				// map is still keyed by the old ones, so re-key it the same
+				// way the RenameTableStmt case above does.
+				t.dropTableLocked(schema, name)
+				schema, name = info.Schema, info.Name
+				t.setTableLocked(info)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetTable returns the tracked column list for schema.table. If the table
+// isn't tracked yet but a source connection is available (SetSource or
+// Bootstrap was called), it tries a one-shot live SHOW CREATE TABLE before
+// giving up, so a table created or routed to after startup can still be
+// resolved without a full re-bootstrap.
+func (t *Tracker) GetTable(schema, name string) (*TableInfo, bool) {
+	t.mu.RLock()
+	info, ok := t.tableLocked(schema, name)
+	t.mu.RUnlock()
+	if ok || t.db == nil {
+		return info, ok
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if info, ok := t.tableLocked(schema, name); ok {
+		return info, true
+	}
+	dumped, err := t.dumpTable(schema, name)
+	if err != nil {
+		return nil, false
+	}
+	t.setTableLocked(dumped)
+	return dumped, true
+}
+
+// SetSource lets the tracker dump a table on demand via GetTable even when
+// Bootstrap was never called with a full table list, e.g. when the tracker
+// is created lazily on the first DDL/DML event of a task.
+func (t *Tracker) SetSource(db *sql.DB) {
+	t.mu.Lock()
+	t.db = db
+	t.mu.Unlock()
+}
+
+// DiscardNoMetaRowEvent reports whether a row event for schema.table should
+// be dropped because the tracker has no schema for it, recording the table
+// in a rate-limited log so operators can notice without flooding the log.
+func (t *Tracker) DiscardNoMetaRowEvent(schema, name string) bool {
+	if _, ok := t.GetTable(schema, name); ok {
+		return false
+	}
+	t.unknown.mark(schema, name)
+	return true
+}
+
+// UpdatePosition records the binlog position the current schema state
+// corresponds to, for inclusion in the next Flush.
+func (t *Tracker) UpdatePosition(pos mysql.Position) {
+	t.mu.Lock()
+	t.position = pos
+	t.mu.Unlock()
+}
+
+// Flush persists the current schema state and position to storage.
+func (t *Tracker) Flush() error {
+	t.mu.RLock()
+	snap := &Snapshot{Position: t.position}
+	for _, tables := range t.schemas {
+		for _, info := range tables {
+			snap.Tables = append(snap.Tables, info)
+		}
+	}
+	t.mu.RUnlock()
+
+	return errors.Trace(t.storage.Save(t.taskName, snap))
+}
+
+func (t *Tracker) createSchema(schema string) {
+	if _, ok := t.schemas[schema]; !ok {
+		t.schemas[schema] = make(map[string]*TableInfo)
+	}
+}
+
+func (t *Tracker) setTable(info *TableInfo) {
+	t.mu.Lock()
+	t.setTableLocked(info)
+	t.mu.Unlock()
+}
+
+func (t *Tracker) setTableLocked(info *TableInfo) {
+	t.createSchema(info.Schema)
+	t.schemas[info.Schema][info.Name] = info
+}
+
+func (t *Tracker) dropTableLocked(schema, name string) {
+	if tables, ok := t.schemas[schema]; ok {
+		delete(tables, name)
+	}
+}
+
+// likeColumnsLocked resolves the column list for `CREATE TABLE ... LIKE
+// referTable`, bootstrapping referTable from the source first if the
+// tracker hasn't seen it yet. Must be called with t.mu held.
+func (t *Tracker) likeColumnsLocked(defaultSchema string, referTable *ast.TableName) ([]string, error) {
+	schema, name := qualify(defaultSchema, referTable)
+
+	info, ok := t.tableLocked(schema, name)
+	if !ok {
+		dumped, err := t.dumpTable(schema, name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		t.setTableLocked(dumped)
+		info = dumped
+	}
+
+	// Copy so later ALTERs on either table don't mutate the other's column
+	// slice through a shared backing array.
+	columns := make([]string, len(info.Columns))
+	copy(columns, info.Columns)
+	return columns, nil
+}
+
+func (t *Tracker) tableLocked(schema, name string) (*TableInfo, bool) {
+	tables, ok := t.schemas[schema]
+	if !ok {
+		return nil, false
+	}
+	info, ok := tables[name]
+	return info, ok
+}
+
+func qualify(defaultSchema string, tn *ast.TableName) (schema, name string) {
+	schema = tn.Schema.O
+	if schema == "" {
+		schema = defaultSchema
+	}
+	return schema, tn.Name.O
+}
+
+func columnsOf(cols []*ast.ColumnDef) []string {
+	names := make([]string, 0, len(cols))
+	for _, col := range cols {
+		names = append(names, col.Name.Name.O)
+	}
+	return names
+}
+
+func applyAlterSpec(info *TableInfo, spec *ast.AlterTableSpec) {
+	switch spec.Tp {
+	case ast.AlterTableAddColumns:
+		info.Columns = append(info.Columns, columnsOf(spec.NewColumns)...)
+	case ast.AlterTableDropColumn:
+		name := spec.OldColumnName.Name.O
+		for i, col := range info.Columns {
+			if col == name {
+				info.Columns = append(info.Columns[:i], info.Columns[i+1:]...)
+				break
+			}
+		}
+	case ast.AlterTableChangeColumn, ast.AlterTableModifyColumn:
+		if len(spec.NewColumns) == 0 {
+			return
+		}
+		newName := spec.NewColumns[0].Name.Name.O
+		oldName := newName
+		if spec.OldColumnName != nil {
+			oldName = spec.OldColumnName.Name.O
+		}
+		for i, col := range info.Columns {
+			if col == oldName {
+				info.Columns[i] = newName
+				break
+			}
+		}
+	case ast.AlterTableRenameTable:
+		if spec.NewTable.Schema.O != "" {
+			info.Schema = spec.NewTable.Schema.O
+		}
+		info.Name = spec.NewTable.Name.O
+	}
+}
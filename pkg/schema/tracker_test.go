@@ -0,0 +1,140 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pingcap/tidb/parser"
+)
+
+func execSQL(t *testing.T, tracker *Tracker, defaultSchema, sql string) {
+	t.Helper()
+	p := parser.New()
+	stmt, err := p.ParseOneStmt(sql, "", "")
+	if err != nil {
+		t.Fatalf("parse %q: %v", sql, err)
+	}
+	if err := tracker.Exec(defaultSchema, stmt); err != nil {
+		t.Fatalf("exec %q: %v", sql, err)
+	}
+}
+
+func newTestTracker(t *testing.T) *Tracker {
+	t.Helper()
+	tracker, err := NewTracker("test-task", NewMemoryStorage())
+	if err != nil {
+		t.Fatalf("NewTracker: %v", err)
+	}
+	return tracker
+}
+
+func TestTrackerCreateTable(t *testing.T) {
+	tracker := newTestTracker(t)
+	execSQL(t, tracker, "test", "CREATE TABLE t1 (id INT, name VARCHAR(20))")
+
+	info, ok := tracker.GetTable("test", "t1")
+	if !ok {
+		t.Fatal("expected t1 to be tracked")
+	}
+	if want := []string{"id", "name"}; !reflect.DeepEqual(info.Columns, want) {
+		t.Errorf("t1 columns = %v, want %v", info.Columns, want)
+	}
+}
+
+func TestTrackerCreateTableLike(t *testing.T) {
+	tracker := newTestTracker(t)
+	execSQL(t, tracker, "test", "CREATE TABLE old_tbl (id INT, name VARCHAR(20))")
+	execSQL(t, tracker, "test", "CREATE TABLE new_tbl LIKE old_tbl")
+
+	info, ok := tracker.GetTable("test", "new_tbl")
+	if !ok {
+		t.Fatal("expected new_tbl to be tracked")
+	}
+	want := []string{"id", "name"}
+	if !reflect.DeepEqual(info.Columns, want) {
+		t.Errorf("new_tbl columns = %v, want %v", info.Columns, want)
+	}
+
+	// Mutating new_tbl's columns (e.g. via a later ALTER) must not affect
+	// old_tbl through a shared backing array.
+	info.Columns[0] = "changed"
+	oldInfo, ok := tracker.GetTable("test", "old_tbl")
+	if !ok {
+		t.Fatal("expected old_tbl to still be tracked")
+	}
+	if oldInfo.Columns[0] != "id" {
+		t.Errorf("old_tbl.Columns was mutated through new_tbl's slice: %v", oldInfo.Columns)
+	}
+}
+
+func TestTrackerCreateTableLikeUnknownReference(t *testing.T) {
+	tracker := newTestTracker(t)
+
+	p := parser.New()
+	stmt, err := p.ParseOneStmt("CREATE TABLE new_tbl LIKE old_tbl", "", "")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	// old_tbl was never tracked and there's no source connection to dump it
+	// from, so this must fail instead of silently registering new_tbl with
+	// zero columns.
+	if err := tracker.Exec("test", stmt); err == nil {
+		t.Fatal("expected Exec to fail resolving an unknown LIKE reference")
+	}
+}
+
+func TestTrackerAlterTableAddColumn(t *testing.T) {
+	tracker := newTestTracker(t)
+	execSQL(t, tracker, "test", "CREATE TABLE t1 (id INT)")
+	execSQL(t, tracker, "test", "ALTER TABLE t1 ADD COLUMN name VARCHAR(20)")
+
+	info, ok := tracker.GetTable("test", "t1")
+	if !ok {
+		t.Fatal("expected t1 to be tracked")
+	}
+	if want := []string{"id", "name"}; !reflect.DeepEqual(info.Columns, want) {
+		t.Errorf("t1 columns after ADD COLUMN = %v, want %v", info.Columns, want)
+	}
+}
+
+func TestTrackerDropTable(t *testing.T) {
+	tracker := newTestTracker(t)
+	execSQL(t, tracker, "test", "CREATE TABLE t1 (id INT)")
+	execSQL(t, tracker, "test", "DROP TABLE t1")
+
+	if _, ok := tracker.GetTable("test", "t1"); ok {
+		t.Fatal("expected t1 to be dropped")
+	}
+}
+
+func TestTrackerAlterTableRenameTable(t *testing.T) {
+	tracker := newTestTracker(t)
+	execSQL(t, tracker, "test", "CREATE TABLE t1 (id INT)")
+	execSQL(t, tracker, "test", "ALTER TABLE t1 RENAME TO t2")
+
+	if _, ok := tracker.GetTable("test", "t1"); ok {
+		t.Fatal("expected t1 to no longer be tracked under its old name")
+	}
+
+	info, ok := tracker.GetTable("test", "t2")
+	if !ok {
+		t.Fatal("expected t2 to be tracked under its new name")
+	}
+	if want := []string{"id"}; !reflect.DeepEqual(info.Columns, want) {
+		t.Errorf("t2 columns = %v, want %v", info.Columns, want)
+	}
+}
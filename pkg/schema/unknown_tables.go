@@ -0,0 +1,54 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ngaut/log"
+)
+
+// logEvery bounds how often we log about the same unknown table, so a
+// sustained stream of row events for a dropped/untracked table doesn't
+// flood the log.
+const logEvery = time.Minute
+
+// unknownTables rate-limits logging for row events that reference a table
+// the tracker has no schema for.
+type unknownTables struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newUnknownTables() *unknownTables {
+	return &unknownTables{seen: make(map[string]time.Time)}
+}
+
+func (u *unknownTables) mark(schema, name string) {
+	key := schema + "." + name
+
+	u.mu.Lock()
+	last, ok := u.seen[key]
+	now := time.Now()
+	shouldLog := !ok || now.Sub(last) >= logEvery
+	if shouldLog {
+		u.seen[key] = now
+	}
+	u.mu.Unlock()
+
+	if shouldLog {
+		log.Warnf("[schema] dropping row event for untracked table `%s`.`%s`", schema, name)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/siddontang/go-mysql/mysql"
+	"github.com/siddontang/go-mysql/replication"
+)
+
+// HandleRowsEvent is the row-event entry point: it decides whether the event
+// should be skipped (bwList/binlogFilter/no-tracked-schema), looks up the
+// row's column list from the schema tracker rather than the live source,
+// and otherwise times and invokes apply with those columns, reports the
+// measured latency to observers, and detects binlog rotation. apply
+// actually executes the row against the target; callers own that logic
+// since it's specific to how they connect to the target database. columns
+// is nil if the tracker has no schema for this table (DiscardNoMetaRowEvent
+// is false, so the event wasn't dropped) — apply must fall back to a live
+// lookup in that case.
+func (s *Syncer) HandleRowsEvent(schemaName, table string, evt replication.EventType, pos mysql.Position, apply func(columns []string) error) error {
+	if err := s.ensureSchemaTracker(); err != nil {
+		return errors.Trace(err)
+	}
+
+	skip, err := s.skipDMLEvent(schemaName, table, evt)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if skip {
+		return nil
+	}
+
+	columns, _ := s.lookupColumns(schemaName, table)
+
+	start := time.Now()
+	if err := apply(columns); err != nil {
+		return errors.Annotatef(err, "apply row event on `%s`.`%s`", schemaName, table)
+	}
+	s.notifyRowApplied(schemaName, table, evt, time.Since(start))
+
+	s.notifyRotateIfChanged(pos)
+	return nil
+}
+
+// notifyRotateIfChanged tells observers about a binlog rotation the first
+// time a position in a new file is seen.
+func (s *Syncer) notifyRotateIfChanged(pos mysql.Position) {
+	if pos.Name == "" || pos.Name == s.lastRotateFile {
+		return
+	}
+	s.lastRotateFile = pos.Name
+	s.notifyRotate(pos)
+}
+
+// Close releases resources owned directly by the Syncer, such as the
+// background goroutine behind the observer registry. Safe to call even if
+// no observer was ever registered.
+func (s *Syncer) Close() {
+	if s.observerRegistry != nil {
+		s.observerRegistry.close()
+	}
+}
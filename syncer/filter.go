@@ -18,9 +18,11 @@ import (
 	"strings"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb-enterprise-tools/pkg/filter"
 	bf "github.com/pingcap/tidb-tools/pkg/binlog-filter"
 	"github.com/pingcap/tidb/ast"
+	"github.com/siddontang/go-mysql/mysql"
 	"github.com/siddontang/go-mysql/replication"
 )
 
@@ -113,7 +115,13 @@ func init() {
 	builtInSkipDDLPatterns = regexp.MustCompile("(?i)" + strings.Join(builtInSkipDDLs, "|"))
 }
 
-func (s *Syncer) skipQuery(tables []*filter.Table, sql string) (bool, error) {
+func (s *Syncer) skipQuery(tables []*filter.Table, sql string) (skip bool, err error) {
+	defer func() {
+		if skip {
+			s.notifyQuerySkipped(sql, tables)
+		}
+	}()
+
 	if builtInSkipDDLPatterns.FindStringIndex(sql) != nil {
 		return true, nil
 	}
@@ -160,7 +168,23 @@ func (s *Syncer) skipQuery(tables []*filter.Table, sql string) (bool, error) {
 	return false, nil
 }
 
-func (s *Syncer) skipDDLEvent(tables []*filter.Table, stmt ast.StmtNode) (bool, error) {
+func (s *Syncer) skipDDLEvent(tables []*filter.Table, stmt ast.StmtNode, pos mysql.Position) (skip bool, err error) {
+	defer func() {
+		if err != nil {
+			return
+		}
+		if skip {
+			s.notifyQuerySkipped("", tables)
+			return
+		}
+		// Not skipped: feed the statement into the schema tracker and let
+		// observers know, same as a live event loop would after actually
+		// applying the DDL against the target.
+		if trackErr := s.onDDLApplied(tables, stmt, pos); trackErr != nil {
+			err = trackErr
+		}
+	}()
+
 	for _, table := range tables {
 		if filter.IsSystemSchema(table.Schema) {
 			return true, nil
@@ -203,18 +227,34 @@ func (s *Syncer) skipDDLEvent(tables []*filter.Table, stmt ast.StmtNode) (bool,
 	return false, nil
 }
 
-func (s *Syncer) skipDMLEvent(schema string, table string, eventType replication.EventType) (bool, error) {
+func (s *Syncer) skipDMLEvent(schema string, table string, eventType replication.EventType) (skip bool, err error) {
+	skipReason := "filtered by bwList/binlogFilter"
+	defer func() {
+		if skip {
+			s.notifyRowSkipped(skipReason, schema, table)
+		}
+	}()
+
 	if filter.IsSystemSchema(schema) {
 		return true, nil
 	}
 
 	schema = strings.ToLower(schema)
 	table = strings.ToLower(table)
-	tbs := []*filter.Table{{schema, table}}
-	tbs = s.bwList.ApplyOn(tbs)
-	if len(tbs) == 0 {
+	if matched, reason := s.bwList.Match(schema, table); !matched {
+		log.Debugf("[syncer] skip row event on `%s`.`%s`: %s", schema, table, reason)
+		return true, nil
+	}
+
+	// The schema tracker gets the final say before the live-source filters:
+	// if it can't resolve (and, if needed, lazily re-dump) this table's
+	// columns, DiscardNoMetaRowEvent lets the task drop the row instead of
+	// failing the whole sync.
+	if s.discardNoMetaRowEvent(schema, table) {
+		skipReason = "no tracked schema for table"
 		return true, nil
 	}
+
 	if s.binlogFilter == nil {
 		return false, nil
 	}
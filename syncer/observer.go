@@ -0,0 +1,187 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb-enterprise-tools/pkg/filter"
+	"github.com/siddontang/go-mysql/mysql"
+	"github.com/siddontang/go-mysql/replication"
+)
+
+// Observer lets external code watch what the syncer is doing without being
+// on the hot path of replication itself, the same role canal's observer
+// hooks play for go-mysql-based tools.
+type Observer interface {
+	// OnRotate is called whenever the syncer switches to a new binlog file.
+	OnRotate(pos mysql.Position)
+	// OnDDL is called for every DDL the syncer applies, pre being the
+	// statement as read from the binlog and post the (possibly rewritten)
+	// statement actually executed against the target.
+	OnDDL(pre, post string, tables []*filter.Table)
+	// OnRowSkipped is called whenever a DDL or DML event is dropped instead
+	// of applied, naming why.
+	OnRowSkipped(reason, schema, table string)
+	// OnRowApplied is called after a DML row event is successfully applied.
+	OnRowApplied(schema, table string, evt replication.EventType, latency time.Duration)
+	// OnCheckpointFlushed is called after the syncer persists its
+	// checkpoint at pos.
+	OnCheckpointFlushed(pos mysql.Position)
+}
+
+// observerQueueSize bounds how many pending observer dispatches can be
+// buffered before we start dropping them; a slow observer must never stall
+// replication.
+const observerQueueSize = 1024
+
+// observerRegistry holds the observers registered on a Syncer and fans
+// dispatches out to them on a background goroutine.
+type observerRegistry struct {
+	mu        sync.RWMutex
+	observers []Observer
+
+	queue   chan func(Observer)
+	dropped uint64
+
+	once sync.Once
+	done chan struct{}
+}
+
+func newObserverRegistry() *observerRegistry {
+	r := &observerRegistry{
+		queue: make(chan func(Observer), observerQueueSize),
+		done:  make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *observerRegistry) run() {
+	for {
+		select {
+		case fn := <-r.queue:
+			r.mu.RLock()
+			observers := r.observers
+			r.mu.RUnlock()
+			for _, o := range observers {
+				fn(o)
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// register adds o to the set of observers notified of future events.
+func (r *observerRegistry) register(o Observer) {
+	r.mu.Lock()
+	r.observers = append(r.observers, o)
+	r.mu.Unlock()
+}
+
+// dispatch queues fn to run against every registered observer. It never
+// blocks: if the queue is full, the dispatch is dropped and counted.
+func (r *observerRegistry) dispatch(fn func(Observer)) {
+	select {
+	case r.queue <- fn:
+	default:
+		dropped := atomic.AddUint64(&r.dropped, 1)
+		if dropped%100 == 1 {
+			log.Warnf("[syncer] observer queue full, dropped %d events so far", dropped)
+		}
+	}
+}
+
+func (r *observerRegistry) close() {
+	r.once.Do(func() { close(r.done) })
+}
+
+// RegisterObserver adds an Observer to be notified of future syncer
+// activity. Safe for concurrent use.
+func (s *Syncer) RegisterObserver(o Observer) {
+	s.observers().register(o)
+}
+
+// observerRegistryInitMu serialises the lazy creation in observers, so two
+// concurrent RegisterObserver calls can't each create their own registry.
+var observerRegistryInitMu sync.Mutex
+
+// observers lazily creates the syncer's observer registry, so Syncer values
+// built without one (e.g. in tests) don't pay for the dispatch goroutine
+// unless something actually registers an observer.
+func (s *Syncer) observers() *observerRegistry {
+	observerRegistryInitMu.Lock()
+	defer observerRegistryInitMu.Unlock()
+	if s.observerRegistry == nil {
+		s.observerRegistry = newObserverRegistry()
+	}
+	return s.observerRegistry
+}
+
+func (s *Syncer) notifyRowSkipped(reason, schema, table string) {
+	if s.observerRegistry == nil {
+		return
+	}
+	s.observerRegistry.dispatch(func(o Observer) { o.OnRowSkipped(reason, schema, table) })
+}
+
+// notifyQuerySkipped reports a skipped DDL query, one OnRowSkipped call per
+// table it would otherwise have touched (or a single schema-less call for
+// statements, like SET, that don't name any table).
+func (s *Syncer) notifyQuerySkipped(sql string, tables []*filter.Table) {
+	if s.observerRegistry == nil {
+		return
+	}
+
+	reason := "ddl skipped: " + sql
+	if len(tables) == 0 {
+		s.notifyRowSkipped(reason, "", "")
+		return
+	}
+	for _, tb := range tables {
+		s.notifyRowSkipped(reason, tb.Schema, tb.Name)
+	}
+}
+
+func (s *Syncer) notifyDDL(pre, post string, tables []*filter.Table) {
+	if s.observerRegistry == nil {
+		return
+	}
+	s.observerRegistry.dispatch(func(o Observer) { o.OnDDL(pre, post, tables) })
+}
+
+func (s *Syncer) notifyRowApplied(schema, table string, evt replication.EventType, latency time.Duration) {
+	if s.observerRegistry == nil {
+		return
+	}
+	s.observerRegistry.dispatch(func(o Observer) { o.OnRowApplied(schema, table, evt, latency) })
+}
+
+func (s *Syncer) notifyCheckpointFlushed(pos mysql.Position) {
+	if s.observerRegistry == nil {
+		return
+	}
+	s.observerRegistry.dispatch(func(o Observer) { o.OnCheckpointFlushed(pos) })
+}
+
+func (s *Syncer) notifyRotate(pos mysql.Position) {
+	if s.observerRegistry == nil {
+		return
+	}
+	s.observerRegistry.dispatch(func(o Observer) { o.OnRotate(pos) })
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb-enterprise-tools/pkg/filter"
+	"github.com/pingcap/tidb/ast"
+	"github.com/siddontang/go-mysql/mysql"
+	"github.com/siddontang/go-mysql/replication"
+)
+
+// RowsEvent is a binlog row event to run through HandleRowsEvent. Apply
+// actually executes the row against the target; it's supplied by the
+// caller because how a row gets turned into target SQL is specific to the
+// binlog-streaming layer feeding Run, which lives outside this package.
+type RowsEvent struct {
+	Schema string
+	Table  string
+	Type   replication.EventType
+	Pos    mysql.Position
+	Apply  func(columns []string) error
+}
+
+// QueryEvent is a binlog query (DDL) event to run through skipDDLEvent.
+type QueryEvent struct {
+	Tables []*filter.Table
+	Stmt   ast.StmtNode
+	Pos    mysql.Position
+}
+
+// Event is one binlog event read off the source, tagged by which of Rows or
+// Query is set.
+type Event struct {
+	Rows  *RowsEvent
+	Query *QueryEvent
+}
+
+// Run is the syncer's main loop: it bootstraps the schema tracker against
+// db so row events can be resolved without a live source from the start,
+// then dispatches every event off events to HandleRowsEvent or
+// skipDDLEvent until events is closed or ctx is cancelled. db and tables
+// are the source connection and replicated table list used only for that
+// initial bootstrap; events carries the live stream produced by the
+// binlog-streaming layer above this package.
+func (s *Syncer) Run(ctx context.Context, db *sql.DB, tables []*filter.Table, events <-chan Event) error {
+	if err := s.bootstrapSchemaTracker(db, tables); err != nil {
+		return errors.Trace(err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case evt, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			switch {
+			case evt.Rows != nil:
+				r := evt.Rows
+				if err := s.HandleRowsEvent(r.Schema, r.Table, r.Type, r.Pos, r.Apply); err != nil {
+					return errors.Trace(err)
+				}
+			case evt.Query != nil:
+				q := evt.Query
+				if _, err := s.skipDDLEvent(q.Tables, q.Stmt, q.Pos); err != nil {
+					return errors.Trace(err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"database/sql"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb-enterprise-tools/pkg/filter"
+	"github.com/pingcap/tidb-enterprise-tools/pkg/schema"
+	"github.com/pingcap/tidb/ast"
+	"github.com/siddontang/go-mysql/mysql"
+)
+
+// bootstrapSchemaTracker builds s.tracker (if it doesn't exist yet) and
+// seeds it by dumping every table in tables that survives s.bwList, so the
+// tracker can resolve row events without falling back to a live source for
+// anything it hasn't personally seen a DDL for. Unlike ensureSchemaTracker,
+// this does real work every time it's called; callers run it once at
+// startup, before processing any event.
+func (s *Syncer) bootstrapSchemaTracker(db *sql.DB, tables []*filter.Table) error {
+	if s.tracker == nil {
+		tracker, err := schema.NewTracker(s.cfg.Name, s.schemaStorage())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		s.tracker = tracker
+	}
+	s.tracker.SetSource(s.fromDB)
+
+	return errors.Trace(s.tracker.Bootstrap(db, tables, s.bwList))
+}
+
+// ensureSchemaTracker lazily builds s.tracker the first time a DDL or DML
+// event needs it, pointing it at the source connection so it can dump a
+// table's columns on demand (GetTable falls back to a live SHOW CREATE
+// TABLE for anything it hasn't seen yet, e.g. because it was routed to
+// after this task started). Safe to call repeatedly; only the first call
+// does anything.
+func (s *Syncer) ensureSchemaTracker() error {
+	if s.tracker != nil {
+		return nil
+	}
+
+	tracker, err := schema.NewTracker(s.cfg.Name, s.schemaStorage())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tracker.SetSource(s.fromDB)
+
+	s.tracker = tracker
+	return nil
+}
+
+// schemaStorage picks where schema snapshots are persisted: in-memory for
+// tests (or whenever re-dumping on restart is acceptable), a file under the
+// task's meta directory otherwise.
+func (s *Syncer) schemaStorage() schema.Storage {
+	if s.cfg.MetaDir == "" {
+		return schema.NewMemoryStorage()
+	}
+
+	storage, err := schema.NewFileStorage(s.cfg.MetaDir)
+	if err != nil {
+		log.Warnf("[syncer] fall back to in-memory schema storage: %v", err)
+		return schema.NewMemoryStorage()
+	}
+	return storage
+}
+
+// trackDDL feeds a DDL statement that already passed skipQuery/skipDDLEvent
+// into the schema tracker, so subsequent row events see the post-DDL schema.
+func (s *Syncer) trackDDL(defaultSchema string, stmt ast.StmtNode) error {
+	if s.tracker == nil {
+		return nil
+	}
+	return errors.Trace(s.tracker.Exec(defaultSchema, stmt))
+}
+
+// lookupColumns returns the tracked column list for a row event's table,
+// used in place of a live DESC/SHOW CREATE TABLE against the source.
+func (s *Syncer) lookupColumns(schemaName, table string) ([]string, bool) {
+	if s.tracker == nil {
+		return nil, false
+	}
+	info, ok := s.tracker.GetTable(schemaName, table)
+	if !ok {
+		return nil, false
+	}
+	return info.Columns, true
+}
+
+// discardNoMetaRowEvent reports whether a row event should be dropped
+// because the tracker has no schema for its table, when the task is
+// configured with DiscardNoMetaRowEvent instead of failing the sync.
+func (s *Syncer) discardNoMetaRowEvent(schemaName, table string) bool {
+	if !s.cfg.DiscardNoMetaRowEvent || s.tracker == nil {
+		return false
+	}
+	return s.tracker.DiscardNoMetaRowEvent(schemaName, table)
+}
+
+// flushSchema persists the tracker's current state alongside pos, so a
+// restart resumes without re-dumping every table.
+func (s *Syncer) flushSchema(pos mysql.Position) error {
+	if s.tracker == nil {
+		return nil
+	}
+	s.tracker.UpdatePosition(pos)
+	return errors.Trace(s.tracker.Flush())
+}
+
+// onDDLApplied runs once skipDDLEvent has decided not to skip a DDL
+// statement: it feeds the statement into the schema tracker, persists the
+// tracker's state at pos, and tells observers about both the DDL and the
+// resulting checkpoint flush. tables is passed on to observers, and the
+// schema of its first entry is the default schema for statements (e.g.
+// CREATE TABLE without a qualifier) that don't name one of their own.
+func (s *Syncer) onDDLApplied(tables []*filter.Table, stmt ast.StmtNode, pos mysql.Position) error {
+	if err := s.ensureSchemaTracker(); err != nil {
+		return errors.Trace(err)
+	}
+
+	var defaultSchema string
+	if len(tables) > 0 {
+		defaultSchema = tables[0].Schema
+	}
+	if err := s.trackDDL(defaultSchema, stmt); err != nil {
+		return errors.Trace(err)
+	}
+
+	sql := stmt.Text()
+	s.notifyDDL(sql, sql, tables)
+
+	if err := s.flushSchema(pos); err != nil {
+		return errors.Trace(err)
+	}
+	s.notifyCheckpointFlushed(pos)
+
+	return nil
+}